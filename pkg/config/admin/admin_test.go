@@ -0,0 +1,56 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package admin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dapr/dapr/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPutPolicyRejectsInvalidDefaultAction(t *testing.T) {
+	store := NewMemoryPolicyStore(config.AccessControlSpec{DefaultAction: config.AllowAccess})
+	aclStore := config.NewAccessControlListStore(&config.AccessControlList{DefaultAction: config.AllowAccess})
+	mgr, err := NewManager(store, aclStore, config.AccessControlSpec{DefaultAction: config.AllowAccess})
+	require.NoError(t, err)
+
+	err = mgr.PutPolicy(context.Background(), nil, config.AppPolicySpec{AppName: "orderapp", DefaultAction: "maybe"})
+	assert.Error(t, err)
+}
+
+func TestPutPolicyRejectsMissingAppName(t *testing.T) {
+	store := NewMemoryPolicyStore(config.AccessControlSpec{DefaultAction: config.AllowAccess})
+	aclStore := config.NewAccessControlListStore(&config.AccessControlList{DefaultAction: config.AllowAccess})
+	mgr, err := NewManager(store, aclStore, config.AccessControlSpec{DefaultAction: config.AllowAccess})
+	require.NoError(t, err)
+
+	err = mgr.PutPolicy(context.Background(), nil, config.AppPolicySpec{})
+	assert.Error(t, err)
+}
+
+func TestPutPolicyRepublishesAccessControlList(t *testing.T) {
+	store := NewMemoryPolicyStore(config.AccessControlSpec{DefaultAction: config.AllowAccess})
+	aclStore := config.NewAccessControlListStore(&config.AccessControlList{DefaultAction: config.AllowAccess})
+	mgr, err := NewManager(store, aclStore, config.AccessControlSpec{DefaultAction: config.AllowAccess})
+	require.NoError(t, err)
+
+	err = mgr.PutPolicy(context.Background(), nil, config.AppPolicySpec{AppName: "orderapp", DefaultAction: config.DenyAccess})
+	assert.NoError(t, err)
+
+	_, found := aclStore.Get().PolicySpec["orderapp"]
+	assert.True(t, found)
+}
+
+func TestNewManagerRejectsStoreThatDoesNotSupportWrites(t *testing.T) {
+	store := NewKubernetesPolicyStore("daprsystem", "default", nil)
+	aclStore := config.NewAccessControlListStore(&config.AccessControlList{DefaultAction: config.AllowAccess})
+
+	_, err := NewManager(store, aclStore, config.AccessControlSpec{DefaultAction: config.AllowAccess})
+	assert.Error(t, err)
+}
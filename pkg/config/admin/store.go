@@ -0,0 +1,236 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+// Package admin exposes CRUD management of access control policies on top of the ConfigurationSource
+// and AccessControlListStore hot-reload path in pkg/config.
+package admin
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dapr/dapr/pkg/config"
+	operatorv1pb "github.com/dapr/dapr/pkg/proto/operator/v1"
+	"github.com/pkg/errors"
+)
+
+// PolicyStore persists AppPolicySpec entries on behalf of the admin API. Implementations are
+// in-memory (standalone) or CRD-backed (Kubernetes, via the operator client).
+type PolicyStore interface {
+	List(ctx context.Context) ([]config.AppPolicySpec, error)
+	Get(ctx context.Context, appID string) (*config.AppPolicySpec, error)
+	Put(ctx context.Context, policy config.AppPolicySpec) error
+	Delete(ctx context.Context, appID string) error
+	DefaultAction(ctx context.Context) (string, error)
+	SetDefaultAction(ctx context.Context, action string) error
+
+	// SupportsWrites reports whether Put, Delete and SetDefaultAction are backed by a real write
+	// path. NewManager rejects a store that returns false here, so an admin API that can never
+	// actually write is refused at startup instead of discovered the first time an operator calls
+	// PutPolicy.
+	SupportsWrites() bool
+}
+
+// MemoryPolicyStore is a PolicyStore backed by an in-memory map, used by standalone mode.
+type MemoryPolicyStore struct {
+	mu            sync.RWMutex
+	defaultAction string
+	policies      map[string]config.AppPolicySpec
+}
+
+// NewMemoryPolicyStore creates a MemoryPolicyStore seeded from an existing AccessControlSpec.
+func NewMemoryPolicyStore(spec config.AccessControlSpec) *MemoryPolicyStore {
+	s := &MemoryPolicyStore{
+		defaultAction: spec.DefaultAction,
+		policies:      make(map[string]config.AppPolicySpec),
+	}
+	for _, policy := range spec.AppPolicies {
+		s.policies[policy.AppName] = policy
+	}
+	return s
+}
+
+func (s *MemoryPolicyStore) List(ctx context.Context) ([]config.AppPolicySpec, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	policies := make([]config.AppPolicySpec, 0, len(s.policies))
+	for _, policy := range s.policies {
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+func (s *MemoryPolicyStore) Get(ctx context.Context, appID string) (*config.AppPolicySpec, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	policy, found := s.policies[appID]
+	if !found {
+		return nil, errors.Errorf("no access control policy for app %q", appID)
+	}
+	return &policy, nil
+}
+
+func (s *MemoryPolicyStore) Put(ctx context.Context, policy config.AppPolicySpec) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.policies[policy.AppName] = policy
+	return nil
+}
+
+func (s *MemoryPolicyStore) Delete(ctx context.Context, appID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.policies, appID)
+	return nil
+}
+
+func (s *MemoryPolicyStore) DefaultAction(ctx context.Context) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.defaultAction, nil
+}
+
+func (s *MemoryPolicyStore) SetDefaultAction(ctx context.Context, action string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.defaultAction = action
+	return nil
+}
+
+// SupportsWrites always returns true: MemoryPolicyStore's Put, Delete and SetDefaultAction are
+// fully functional.
+func (s *MemoryPolicyStore) SupportsWrites() bool { return true }
+
+// Spec snapshots the store's current state as an AccessControlSpec, suitable for handing to
+// config.TranslateAccessControlSpec as part of a hot-reload.
+func (s *MemoryPolicyStore) Spec(ctx context.Context) (config.AccessControlSpec, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	spec := config.AccessControlSpec{DefaultAction: s.defaultAction}
+	for _, policy := range s.policies {
+		spec.AppPolicies = append(spec.AppPolicies, policy)
+	}
+	return spec, nil
+}
+
+// KubernetesPolicyStore is a PolicyStore backed by the Configuration CRD already used by
+// config.LoadKubernetesConfiguration. Reads (List, Get, DefaultAction) work today; Put, Delete
+// and SetDefaultAction are implemented but always fail, because the operator API in this tree
+// does not yet expose a write RPC. SupportsWrites reports false for exactly this reason, and
+// NewManager refuses to build an admin Manager on top of this store until one exists.
+type KubernetesPolicyStore struct {
+	configName     string
+	namespace      string
+	operatorClient operatorv1pb.OperatorClient
+}
+
+// NewKubernetesPolicyStore creates a read-only PolicyStore backed by the named Configuration CRD.
+// It cannot be used to construct an admin Manager (see SupportsWrites); callers that only need
+// List/Get/DefaultAction, e.g. to mirror the current policy elsewhere, may still use it directly.
+func NewKubernetesPolicyStore(configName, namespace string, operatorClient operatorv1pb.OperatorClient) *KubernetesPolicyStore {
+	return &KubernetesPolicyStore{configName: configName, namespace: namespace, operatorClient: operatorClient}
+}
+
+func (s *KubernetesPolicyStore) currentSpec(ctx context.Context) (config.AccessControlSpec, error) {
+	conf, err := config.LoadKubernetesConfiguration(s.configName, s.namespace, s.operatorClient)
+	if err != nil {
+		return config.AccessControlSpec{}, err
+	}
+	return conf.Spec.AccessControlSpec, nil
+}
+
+func (s *KubernetesPolicyStore) List(ctx context.Context) ([]config.AppPolicySpec, error) {
+	spec, err := s.currentSpec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return spec.AppPolicies, nil
+}
+
+func (s *KubernetesPolicyStore) Get(ctx context.Context, appID string) (*config.AppPolicySpec, error) {
+	spec, err := s.currentSpec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, policy := range spec.AppPolicies {
+		if policy.AppName == appID {
+			return &policy, nil
+		}
+	}
+	return nil, errors.Errorf("no access control policy for app %q", appID)
+}
+
+func (s *KubernetesPolicyStore) Put(ctx context.Context, policy config.AppPolicySpec) error {
+	spec, err := s.currentSpec(ctx)
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i, existing := range spec.AppPolicies {
+		if existing.AppName == policy.AppName {
+			spec.AppPolicies[i] = policy
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		spec.AppPolicies = append(spec.AppPolicies, policy)
+	}
+
+	return s.updateCRD(ctx, spec)
+}
+
+func (s *KubernetesPolicyStore) Delete(ctx context.Context, appID string) error {
+	spec, err := s.currentSpec(ctx)
+	if err != nil {
+		return err
+	}
+
+	filtered := spec.AppPolicies[:0]
+	for _, existing := range spec.AppPolicies {
+		if existing.AppName != appID {
+			filtered = append(filtered, existing)
+		}
+	}
+	spec.AppPolicies = filtered
+
+	return s.updateCRD(ctx, spec)
+}
+
+func (s *KubernetesPolicyStore) DefaultAction(ctx context.Context) (string, error) {
+	spec, err := s.currentSpec(ctx)
+	if err != nil {
+		return "", err
+	}
+	return spec.DefaultAction, nil
+}
+
+func (s *KubernetesPolicyStore) SetDefaultAction(ctx context.Context, action string) error {
+	spec, err := s.currentSpec(ctx)
+	if err != nil {
+		return err
+	}
+	spec.DefaultAction = action
+	return s.updateCRD(ctx, spec)
+}
+
+// updateCRD persists spec back to the Configuration CRD through the operator client. The
+// operator API in this tree does not yet expose a write RPC; wiring it in is a drop-in change
+// behind this method once one exists. NewManager already refuses to offer this store for writes,
+// so this only fires if Put, Delete or SetDefaultAction is called directly, bypassing Manager.
+func (s *KubernetesPolicyStore) updateCRD(ctx context.Context, spec config.AccessControlSpec) error {
+	return errors.New("updating the Configuration CRD from the admin API requires an operator write RPC that is not yet available")
+}
+
+// SupportsWrites always returns false: see the KubernetesPolicyStore doc comment.
+func (s *KubernetesPolicyStore) SupportsWrites() bool { return false }
@@ -0,0 +1,275 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AuditRecord is a structured record of a single access control policy decision.
+type AuditRecord struct {
+	Timestamp               time.Time `json:"timestamp"`
+	SourceAppID             string    `json:"sourceAppId"`
+	SourceTrustDomain       string    `json:"sourceTrustDomain,omitempty"`
+	SourceNamespace         string    `json:"sourceNamespace,omitempty"`
+	TargetOperation         string    `json:"targetOperation"`
+	HTTPVerb                string    `json:"httpVerb,omitempty"`
+	MatchedPolicyName       string    `json:"matchedPolicyName,omitempty"`
+	MatchedOperationPattern string    `json:"matchedOperationPattern,omitempty"`
+	Action                  string    `json:"action"`
+	DefaultActionFallback   bool      `json:"defaultActionFallback"`
+}
+
+// AuditSink receives an AuditRecord for every access control decision that passes sampling.
+type AuditSink interface {
+	Emit(record AuditRecord) error
+}
+
+// AuditSinkFunc adapts a plain function to an AuditSink.
+type AuditSinkFunc func(record AuditRecord) error
+
+// Emit implements AuditSink.
+func (f AuditSinkFunc) Emit(record AuditRecord) error { return f(record) }
+
+// StdoutAuditSink writes each AuditRecord as a line of JSON to an io.Writer (os.Stdout by
+// default).
+type StdoutAuditSink struct {
+	Writer io.Writer
+	mu     sync.Mutex
+}
+
+// NewStdoutAuditSink creates a StdoutAuditSink writing to os.Stdout.
+func NewStdoutAuditSink() *StdoutAuditSink {
+	return &StdoutAuditSink{Writer: os.Stdout}
+}
+
+// Emit implements AuditSink.
+func (s *StdoutAuditSink) Emit(record AuditRecord) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = fmt.Fprintln(s.Writer, string(b))
+	return err
+}
+
+// FileAuditSink writes each AuditRecord as a line of JSON to a file, rotating to a new file
+// once the current one reaches maxBytes.
+type FileAuditSink struct {
+	path     string
+	maxBytes int64
+
+	mu      sync.Mutex
+	file    *os.File
+	written int64
+}
+
+// NewFileAuditSink creates a FileAuditSink that rotates path once it exceeds maxBytes.
+func NewFileAuditSink(path string, maxBytes int64) (*FileAuditSink, error) {
+	s := &FileAuditSink{path: path, maxBytes: maxBytes}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileAuditSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.file = f
+	s.written = info.Size()
+	return nil
+}
+
+// Emit implements AuditSink.
+func (s *FileAuditSink) Emit(record AuditRecord) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxBytes > 0 && s.written+int64(len(b)) > s.maxBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(b)
+	s.written += int64(n)
+	return err
+}
+
+func (s *FileAuditSink) rotate() error {
+	s.file.Close()
+	rotatedPath := s.path + "." + strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := os.Rename(s.path, rotatedPath); err != nil {
+		return err
+	}
+	s.written = 0
+	return s.open()
+}
+
+// Close closes the underlying file.
+func (s *FileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// OTelAuditSink hands each AuditRecord, as an event named "dapr.acl.decision" with the record's
+// fields as string attributes, to an injected exporter function, so this package does not take a
+// direct dependency on a particular OpenTelemetry SDK wiring.
+type OTelAuditSink struct {
+	// ExportEvent is called with the audit event name and its attributes for every record.
+	ExportEvent func(name string, attributes map[string]string)
+}
+
+// Emit implements AuditSink.
+func (s *OTelAuditSink) Emit(record AuditRecord) error {
+	if s.ExportEvent == nil {
+		return nil
+	}
+	s.ExportEvent("dapr.acl.decision", map[string]string{
+		"sourceAppId":             record.SourceAppID,
+		"sourceTrustDomain":       record.SourceTrustDomain,
+		"sourceNamespace":         record.SourceNamespace,
+		"targetOperation":         record.TargetOperation,
+		"httpVerb":                record.HTTPVerb,
+		"matchedPolicyName":       record.MatchedPolicyName,
+		"matchedOperationPattern": record.MatchedOperationPattern,
+		"action":                  record.Action,
+		"defaultActionFallback":   strconv.FormatBool(record.DefaultActionFallback),
+	})
+	return nil
+}
+
+// aclDecisionCounter is a minimal in-process counter for dapr_acl_decisions_total{action,reason},
+// exported to Prometheus by the metrics registration code when MetricSpec.Enabled is true.
+type aclDecisionCounter struct {
+	mu     sync.Mutex
+	counts map[[2]string]int64
+}
+
+var aclDecisionsTotal = &aclDecisionCounter{counts: map[[2]string]int64{}}
+
+func (c *aclDecisionCounter) inc(action, reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[[2]string{action, reason}]++
+}
+
+// Count returns the current value of dapr_acl_decisions_total{action=action,reason=reason}.
+func (c *aclDecisionCounter) Count(action, reason string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[[2]string{action, reason}]
+}
+
+// AclDecisionsTotal returns the dapr_acl_decisions_total counter. Decisions are only counted while
+// metrics are enabled (see SetAuditMetricsEnabled).
+func AclDecisionsTotal() *aclDecisionCounter {
+	return aclDecisionsTotal
+}
+
+var (
+	auditMu         sync.RWMutex
+	auditSink       AuditSink
+	metricsEnabled  bool
+	auditSampleRate float64 = 1
+)
+
+// SetAuditSink registers the AuditSink that IsOperationAllowedByAccessControlPolicy emits
+// decisions to. Passing nil disables auditing.
+func SetAuditSink(sink AuditSink) {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	auditSink = sink
+}
+
+// SetAuditSamplingRate controls what fraction of allowed decisions are audited, mirroring
+// TracingSpec.SamplingRate. Denies are always logged regardless of this setting. An empty or
+// unparsable rate defaults to 1 (audit everything).
+func SetAuditSamplingRate(samplingRate string) {
+	rate, err := strconv.ParseFloat(samplingRate, 64)
+	if err != nil || rate < 0 || rate > 1 {
+		rate = 1
+	}
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	auditSampleRate = rate
+}
+
+// SetAuditMetricsEnabled gates the dapr_acl_decisions_total counter behind MetricSpec.Enabled.
+func SetAuditMetricsEnabled(enabled bool) {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	metricsEnabled = enabled
+}
+
+// ConfigureAuditFromSpec wires the audit subsystem's sampling rate and dapr_acl_decisions_total
+// counter gate from spec, mirroring TracingSpec.SamplingRate and MetricSpec.Enabled respectively.
+func ConfigureAuditFromSpec(spec ConfigurationSpec) {
+	SetAuditSamplingRate(spec.TracingSpec.SamplingRate)
+	SetAuditMetricsEnabled(spec.MetricSpec.Enabled)
+}
+
+// EmitAdminAuditRecord routes an admin API policy mutation (see pkg/config/admin.Manager) through
+// the same AuditSink/sampling machinery as IsOperationAllowedByAccessControlPolicy, instead of a
+// second, bespoke logging mechanism.
+func EmitAdminAuditRecord(record AuditRecord) {
+	emitAudit(record)
+}
+
+func emitAudit(record AuditRecord) {
+	auditMu.RLock()
+	sink := auditSink
+	sampleRate := auditSampleRate
+	metrics := metricsEnabled
+	auditMu.RUnlock()
+
+	if metrics {
+		reason := "policy"
+		if record.DefaultActionFallback {
+			reason = "default"
+		}
+		aclDecisionsTotal.inc(record.Action, reason)
+	}
+
+	if sink == nil {
+		return
+	}
+
+	// Always audit denies; sample allows so high-QPS deployments can downsample them.
+	if record.Action == AccessControlActionAllow && sampleRate < 1 && rand.Float64() > sampleRate {
+		return
+	}
+
+	if err := sink.Emit(record); err != nil {
+		watchLog.Errorf("failed to emit access control audit record: %s", err)
+	}
+}
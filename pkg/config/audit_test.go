@@ -0,0 +1,60 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmitAuditAlwaysEmitsDenies(t *testing.T) {
+	var emitted []AuditRecord
+	SetAuditSink(AuditSinkFunc(func(record AuditRecord) error {
+		emitted = append(emitted, record)
+		return nil
+	}))
+	defer SetAuditSink(nil)
+
+	SetAuditSamplingRate("0")
+	defer SetAuditSamplingRate("1")
+
+	emitAudit(AuditRecord{Action: AccessControlActionDeny})
+
+	assert.Len(t, emitted, 1)
+}
+
+func TestEmitAuditSamplesAllows(t *testing.T) {
+	var emitted []AuditRecord
+	SetAuditSink(AuditSinkFunc(func(record AuditRecord) error {
+		emitted = append(emitted, record)
+		return nil
+	}))
+	defer SetAuditSink(nil)
+
+	SetAuditSamplingRate("0")
+	defer SetAuditSamplingRate("1")
+
+	emitAudit(AuditRecord{Action: AccessControlActionAllow})
+
+	assert.Len(t, emitted, 0)
+}
+
+func TestSetAuditSamplingRateDefaultsOnInvalidInput(t *testing.T) {
+	SetAuditSamplingRate("not-a-number")
+	defer SetAuditSamplingRate("1")
+
+	var emitted []AuditRecord
+	SetAuditSink(AuditSinkFunc(func(record AuditRecord) error {
+		emitted = append(emitted, record)
+		return nil
+	}))
+	defer SetAuditSink(nil)
+
+	emitAudit(AuditRecord{Action: AccessControlActionAllow})
+
+	assert.Len(t, emitted, 1)
+}
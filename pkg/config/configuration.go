@@ -7,8 +7,6 @@ package config
 
 import (
 	"context"
-	"encoding/json"
-	"io/ioutil"
 	"os"
 	"sort"
 	"strings"
@@ -17,10 +15,7 @@ import (
 	"github.com/dapr/dapr/pkg/logger"
 	"github.com/dapr/dapr/pkg/proto/common/v1"
 	operatorv1pb "github.com/dapr/dapr/pkg/proto/operator/v1"
-	grpc_retry "github.com/grpc-ecosystem/go-grpc-middleware/retry"
 	"github.com/pkg/errors"
-	"google.golang.org/grpc/peer"
-	yaml "gopkg.in/yaml.v2"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
@@ -43,6 +38,11 @@ type Configuration struct {
 type AccessControlList struct {
 	DefaultAction string
 	PolicySpec    map[string]AppPolicySpec
+	// NamePolicies holds the compiled NamePolicy for every app that declares one, keyed by app name.
+	NamePolicies map[string]*NamePolicy
+	// trustDomainMatchers holds the compiled Matcher for AppPolicySpec.TrustDomain, keyed by app
+	// name, so IsOperationAllowedByAccessControlPolicy does not recompile it on every request.
+	trustDomainMatchers map[string]Matcher
 }
 
 type ConfigurationSpec struct {
@@ -101,6 +101,19 @@ type AppPolicySpec struct {
 	DefaultAction       string         `json:"defaultAction" yaml:"defaultAction"`
 	TrustDomain         string         `json:"trustDomain" yaml:"trustDomain"`
 	AppOperationActions []AppOperation `json:"operations" yaml:"operations"`
+	NamePolicy          NamePolicySpec `json:"namePolicy,omitempty" yaml:"namePolicy,omitempty"`
+}
+
+// NamePolicySpec defines ordered allow/deny lists for operations, trust domains and namespaces.
+// Each entry is either a literal, a glob (e.g. "*.foo.bar", "/v1/orders/*") or a "re:" prefixed
+// regular expression. Lists are compiled once into a NamePolicy by TranslateAccessControlSpec.
+type NamePolicySpec struct {
+	AllowedOperations   []string `json:"allowedOperations,omitempty" yaml:"allowedOperations,omitempty"`
+	DeniedOperations    []string `json:"deniedOperations,omitempty" yaml:"deniedOperations,omitempty"`
+	AllowedTrustDomains []string `json:"allowedTrustDomains,omitempty" yaml:"allowedTrustDomains,omitempty"`
+	DeniedTrustDomains  []string `json:"deniedTrustDomains,omitempty" yaml:"deniedTrustDomains,omitempty"`
+	AllowedNamespaces   []string `json:"allowedNamespaces,omitempty" yaml:"allowedNamespaces,omitempty"`
+	DeniedNamespaces    []string `json:"deniedNamespaces,omitempty" yaml:"deniedNamespaces,omitempty"`
 }
 
 // AppOperation defines the data structure for each app operation
@@ -122,13 +135,6 @@ type MTLSSpec struct {
 	AllowedClockSkew string `json:"allowedClockSkew"`
 }
 
-// SpiffeID represents the separated fields in a spiffe id
-type SpiffeID struct {
-	trustDomain string
-	namespace   string
-	appID       string
-}
-
 // LoadDefaultConfiguration returns the default config
 func LoadDefaultConfiguration() *Configuration {
 	return &Configuration{
@@ -143,55 +149,27 @@ func LoadDefaultConfiguration() *Configuration {
 	}
 }
 
-// LoadStandaloneConfiguration gets the path to a config file and loads it into a configuration
+// LoadStandaloneConfiguration gets the path to a config file and loads it into a configuration.
+// It is a thin wrapper around ConfigLoader for back-compat; new callers that need to layer
+// multiple sources (e.g. a directory of fragments, or environment overrides) should use
+// NewConfigLoader directly.
 func LoadStandaloneConfiguration(config string) (*Configuration, error) {
-	_, err := os.Stat(config)
-	if err != nil {
-		return nil, err
-	}
-
-	b, err := ioutil.ReadFile(config)
-	if err != nil {
+	if _, err := os.Stat(config); err != nil {
 		return nil, err
 	}
 
-	var conf Configuration
-	err = yaml.Unmarshal(b, &conf)
-	if err != nil {
-		return nil, err
-	}
-	err = sortAndValidateSecretsConfiguration(&conf)
-	if err != nil {
-		return nil, err
-	}
-
-	return &conf, nil
+	return NewConfigLoader(&FileProvider{Path: config}).Load(context.Background())
 }
 
-// LoadKubernetesConfiguration gets configuration from the Kubernetes operator with a given name
+// LoadKubernetesConfiguration gets configuration from the Kubernetes operator with a given name.
+// It is a thin wrapper around ConfigLoader for back-compat; new callers that need to layer
+// multiple sources should use NewConfigLoader directly. Routing through ConfigLoader means this
+// goes through the same validateConfigurationSpec checks (duplicate app IDs, trust domain syntax,
+// unknown pipeline handlers, ...) as LoadStandaloneConfiguration, instead of its own hand-rolled,
+// unvalidated path.
 func LoadKubernetesConfiguration(config, namespace string, operatorClient operatorv1pb.OperatorClient) (*Configuration, error) {
-	resp, err := operatorClient.GetConfiguration(context.Background(), &operatorv1pb.GetConfigurationRequest{
-		Name:      config,
-		Namespace: namespace,
-	}, grpc_retry.WithMax(operatorMaxRetries), grpc_retry.WithPerRetryTimeout(operatorCallTimeout))
-	if err != nil {
-		return nil, err
-	}
-	if resp.GetConfiguration() == nil {
-		return nil, errors.Errorf("configuration %s not found", config)
-	}
-	var conf Configuration
-	err = json.Unmarshal(resp.GetConfiguration(), &conf)
-	if err != nil {
-		return nil, err
-	}
-
-	err = sortAndValidateSecretsConfiguration(&conf)
-	if err != nil {
-		return nil, err
-	}
-
-	return &conf, nil
+	provider := &KubernetesProvider{Name: config, Namespace: namespace, OperatorClient: operatorClient}
+	return NewConfigLoader(provider).Load(context.Background())
 }
 
 // Validate the secrets configuration and sort the allow and deny lists if present.
@@ -249,119 +227,124 @@ func containsKey(s []string, key string) bool {
 	return index < len(s) && s[index] == key
 }
 
-// TranslateAccessControlSpec creates an in-memory copy of the Access Control Spec for fast lookup
+// TranslateAccessControlSpec creates an in-memory copy of the Access Control Spec for fast
+// lookup. Callers that accept an AccessControlSpec from outside the process should validate it
+// (e.g. via ValidateNamePolicySpec, or the validators in watch.go/loader.go/the admin package)
+// before calling this function; a NamePolicy that fails to compile here falls back to an
+// unrestricted policy rather than panicking, since TranslateAccessControlSpec has no error
+// return.
 func TranslateAccessControlSpec(accessControlSpec AccessControlSpec, id string) AccessControlList {
+	var log = logger.NewLogger("dapr.configuration")
 	var accessControlList AccessControlList
 	accessControlList.PolicySpec = make(map[string]AppPolicySpec)
+	accessControlList.NamePolicies = make(map[string]*NamePolicy)
+	accessControlList.trustDomainMatchers = make(map[string]Matcher)
 	accessControlList.DefaultAction = strings.ToLower(accessControlSpec.DefaultAction)
-	var log = logger.NewLogger("dapr.configuration")
-	log.Infof("@@@@@ Translating policy spec....")
 
 	for _, appPolicySpec := range accessControlSpec.AppPolicies {
-		log.Infof("@@@@@ name: %s spec: %s", appPolicySpec.AppName, appPolicySpec)
 		accessControlList.PolicySpec[appPolicySpec.AppName] = appPolicySpec
-	}
 
-	return accessControlList
-}
+		namePolicy, err := compileNamePolicy(appPolicySpec.NamePolicy)
+		if err != nil {
+			log.Errorf("app %q has an invalid namePolicy, applying no name policy restrictions: %s", appPolicySpec.AppName, err)
+			namePolicy = nil
+		}
+		accessControlList.NamePolicies[appPolicySpec.AppName] = namePolicy
 
-// TryGetAndParseSpiffeID retrieves the SPIFFE Id from the cert and parses it
-func TryGetAndParseSpiffeID(ctx context.Context) (*SpiffeID, error) {
-	peer, ok := peer.FromContext(ctx)
-	if !ok {
-		return nil, fmt.Errorf("could not retrieve spiffe id from the grpc context")
+		if appPolicySpec.TrustDomain != "" {
+			trustDomainMatcher, err := compileMatcher(appPolicySpec.TrustDomain)
+			if err != nil {
+				log.Errorf("app %q has an invalid trustDomain pattern, denying all trust domains: %s", appPolicySpec.AppName, err)
+				trustDomainMatcher = literalMatcher("")
+			}
+			accessControlList.trustDomainMatchers[appPolicySpec.AppName] = trustDomainMatcher
+		}
 	}
 
-	fmt.Println(peer)
-
-	// if peer.AuthInfo == nil {
-	// 	return nil, fmt.Errorf("could not retrieve auth info from grpc context tls info")
-	// }
-
-	// tlsInfo := peer.AuthInfo.(credentials.TLSInfo)
-
-	// if tlsInfo.State.HandshakeComplete == false {
-	// 	return nil, fmt.Errorf("tls handshake is not complete")
-	// }
-
-	// certChain := tlsInfo.State.VerifiedChains
-	// t := reflect.TypeOf(certChain)
-	// fmt.Println(t)
-	// if certChain == nil || len(certChain[0]) == 0 {
-	// 	return nil, fmt.Errorf("could not retrieve read client cert info")
-	// }
-
-	// TODO: Remove hardcoding for testing
-	// spiffeID := string(certChain[0][0].ExtraExtensions[0].Value)
-	spiffeID := "spiffe://a/ns/b/pythonapp"
-	fmt.Printf("spiffe id :- %v\n", spiffeID)
-
-	// The SPIFFE Id will be of the format: spiffe://<trust-domain/ns/<namespace>/<app-id>
-	parts := strings.Split(spiffeID, "/")
-	var id SpiffeID
-	id.trustDomain = parts[2]
-	id.namespace = parts[4]
-	id.appID = parts[5]
-
-	return &id, nil
+	return accessControlList
 }
 
 // IsOperationAllowedByAccessControlPolicy determines if access control policies allow the operation on the target app
 func IsOperationAllowedByAccessControlPolicy(id *SpiffeID, srcAppID string, operation string, httpVerb common.HTTPExtension_Verb, accessControlList *AccessControlList) bool {
 	var log = logger.NewLogger("dapr.configuration")
-	log.Infof("@@@@ Dumping all policy specs....")
-	for key, spec := range accessControlList.PolicySpec {
-		log.Infof("key: %s, value: %s", key, spec)
-	}
-	log.Infof("Checking access control policy for invocation by %v, operation: %v, httpVerb: %v", srcAppID, operation, httpVerb)
-	action := accessControlList.DefaultAction
 
 	if accessControlList == nil {
 		// No access control list is provided. Do nothing
 		return true
 	}
 
-	policy, found := accessControlList.PolicySpec[srcAppID]
-	log.Infof("@@@@ Using policy spec: %v", policy)
+	record := AuditRecord{
+		Timestamp:       time.Now(),
+		SourceAppID:     srcAppID,
+		TargetOperation: operation,
+		HTTPVerb:        httpVerb.String(),
+	}
 
+	policy, found := accessControlList.PolicySpec[srcAppID]
 	if !found {
-		return isActionAllowed(action)
+		record.Action = accessControlList.DefaultAction
+		record.DefaultActionFallback = true
+		defer emitAudit(record)
+		return isActionAllowed(record.Action)
 	}
 
-	action = policy.DefaultAction
+	record.MatchedPolicyName = policy.AppName
+	action := policy.DefaultAction
+	defer func() {
+		// action may come from policy.DefaultAction or an AppOperationActions entry, neither of
+		// which is normalized to lowercase the way accessControlList.DefaultAction is in
+		// TranslateAccessControlSpec. Normalize here so emitAudit's sampling and the
+		// dapr_acl_decisions_total counter never fragment on casing.
+		record.Action = strings.ToLower(action)
+		emitAudit(record)
+	}()
 
 	if id == nil {
 		log.Errorf("Unable to verify spiffe id of the client. Will apply default access control policy")
-	} else {
-		if policy.TrustDomain != "*" && policy.TrustDomain != id.trustDomain {
-			log.Infof("Trust Domain mismatch does not allow request")
-			return false
-		}
+		return isActionAllowed(action)
+	}
+
+	record.SourceTrustDomain = id.TrustDomain()
+	record.SourceNamespace = id.Namespace()
+
+	if err := validateSpiffeID(id); err != nil {
+		log.Errorf("spiffe id %v failed validation: %s", id, err)
+		action = AccessControlActionDeny
+		return false
+	}
+
+	if trustDomainMatcher := accessControlList.trustDomainMatchers[srcAppID]; trustDomainMatcher != nil && !trustDomainMatcher.Match(id.TrustDomain()) {
+		action = AccessControlActionDeny
+		return false
+	}
+
+	namePolicy := accessControlList.NamePolicies[srcAppID]
+	if !namePolicy.allowsTrustDomain(id.TrustDomain()) ||
+		!namePolicy.allowsNamespace(id.Namespace()) ||
+		!namePolicy.allowsOperation("/"+operation) {
+		action = AccessControlActionDeny
+		return false
+	}
 
-		// TODO: Check namespace if needed
-
-		inputOperation := "/" + operation
-		// Check the operation specific policy
-		for _, policyOperation := range policy.AppOperationActions {
-			if strings.HasPrefix(policyOperation.Operation, inputOperation) {
-				log.Infof("Found operation: %v. checking http verbs", inputOperation)
-				if httpVerb != common.HTTPExtension_NONE {
-					for _, policyVerb := range policyOperation.HTTPVerb {
-						if policyVerb == httpVerb.String() || policyVerb == "*" {
-							action = policyOperation.Action
-							log.Infof("Applying action: %v for srcAppId: %s operation: %v, verb: %v", srcAppID, action, inputOperation, policyVerb)
-							break
-						}
+	inputOperation := "/" + operation
+	// Check the operation specific policy
+	for _, policyOperation := range policy.AppOperationActions {
+		if strings.HasPrefix(policyOperation.Operation, inputOperation) {
+			if httpVerb != common.HTTPExtension_NONE {
+				for _, policyVerb := range policyOperation.HTTPVerb {
+					if policyVerb == httpVerb.String() || policyVerb == "*" {
+						action = policyOperation.Action
+						record.MatchedOperationPattern = policyOperation.Operation
+						break
 					}
-				} else {
-					log.Infof("Applying action: %v for operation: %v", action, inputOperation)
-					action = policyOperation.Action
 				}
+			} else {
+				action = policyOperation.Action
+				record.MatchedOperationPattern = policyOperation.Operation
 			}
 		}
 	}
 
-	log.Infof("Applying access control policy action: %v", action)
 	return isActionAllowed(action)
 }
 
@@ -0,0 +1,396 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	operatorv1pb "github.com/dapr/dapr/pkg/proto/operator/v1"
+	grpc_retry "github.com/grpc-ecosystem/go-grpc-middleware/retry"
+	"github.com/hashicorp/hcl"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// trustDomainPattern is a conservative validation for bare trust-domain values in an
+// AppPolicySpec; "*"/glob and "re:" prefixed entries are handled separately by ValidateTrustDomainSyntax.
+var trustDomainPattern = regexp.MustCompile(`^[a-zA-Z0-9.\-_]+$`)
+
+// Format selects the serialization used to decode a Provider's raw bytes.
+type Format string
+
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+	FormatHCL  Format = "hcl"
+
+	// envProviderPrefix is prepended to every environment variable consulted by EnvProvider.
+	envProviderPrefix = "DAPR_CFG_"
+
+	httpProviderTimeout = time.Second * 10
+)
+
+// Provider supplies one layer of ConfigurationSpec to a ConfigLoader. Providers are evaluated
+// in the order given to NewConfigLoader; later providers override earlier ones.
+type Provider interface {
+	Load(ctx context.Context) (ConfigurationSpec, error)
+}
+
+// ConfigLoader composes an ordered list of Providers into a single, merged Configuration.
+type ConfigLoader struct {
+	providers []Provider
+}
+
+// NewConfigLoader creates a ConfigLoader that merges providers in order, with later entries
+// taking precedence over earlier ones.
+func NewConfigLoader(providers ...Provider) *ConfigLoader {
+	return &ConfigLoader{providers: providers}
+}
+
+// Load runs every provider in order, deep-merges the resulting ConfigurationSpec values, then
+// validates the merged result.
+func (l *ConfigLoader) Load(ctx context.Context) (*Configuration, error) {
+	var merged ConfigurationSpec
+
+	for _, provider := range l.providers {
+		spec, err := provider.Load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeConfigurationSpec(merged, spec)
+	}
+
+	conf := &Configuration{Spec: merged}
+	if err := sortAndValidateSecretsConfiguration(conf); err != nil {
+		return nil, err
+	}
+	if err := validateConfigurationSpec(merged); err != nil {
+		return nil, err
+	}
+
+	return conf, nil
+}
+
+// mergeConfigurationSpec deep-merges override onto base: scalar fields in override win when
+// non-zero, secrets scopes are merged by StoreName and accessControl.policies by AppName (an
+// override entry replaces the base entry of the same name), and pipeline handlers are replaced
+// wholesale. DirectoryProvider uses this same function to merge its fragment files.
+func mergeConfigurationSpec(base, override ConfigurationSpec) ConfigurationSpec {
+	merged := base
+
+	if len(override.HTTPPipelineSpec.Handlers) > 0 {
+		merged.HTTPPipelineSpec = override.HTTPPipelineSpec
+	}
+	if override.TracingSpec.SamplingRate != "" {
+		merged.TracingSpec.SamplingRate = override.TracingSpec.SamplingRate
+	}
+	if override.TracingSpec.Stdout {
+		merged.TracingSpec.Stdout = override.TracingSpec.Stdout
+	}
+	if override.MTLSSpec.Enabled {
+		merged.MTLSSpec = override.MTLSSpec
+	}
+	if override.MetricSpec.Enabled {
+		merged.MetricSpec = override.MetricSpec
+	}
+	if len(override.Secrets.Scopes) > 0 {
+		merged.Secrets.Scopes = mergeSecretsScopes(merged.Secrets.Scopes, override.Secrets.Scopes)
+	}
+	if override.AccessControlSpec.DefaultAction != "" {
+		merged.AccessControlSpec.DefaultAction = override.AccessControlSpec.DefaultAction
+	}
+	if len(override.AccessControlSpec.AppPolicies) > 0 {
+		merged.AccessControlSpec.AppPolicies = mergeAppPolicies(merged.AccessControlSpec.AppPolicies, override.AccessControlSpec.AppPolicies)
+	}
+
+	return merged
+}
+
+// mergeSecretsScopes overlays override scopes onto base by StoreName.
+func mergeSecretsScopes(base, override []SecretsScope) []SecretsScope {
+	byName := make(map[string]SecretsScope, len(base))
+	order := make([]string, 0, len(base))
+	for _, scope := range base {
+		if _, exists := byName[scope.StoreName]; !exists {
+			order = append(order, scope.StoreName)
+		}
+		byName[scope.StoreName] = scope
+	}
+	for _, scope := range override {
+		if _, exists := byName[scope.StoreName]; !exists {
+			order = append(order, scope.StoreName)
+		}
+		byName[scope.StoreName] = scope
+	}
+
+	merged := make([]SecretsScope, 0, len(order))
+	for _, name := range order {
+		merged = append(merged, byName[name])
+	}
+	return merged
+}
+
+// mergeAppPolicies overlays override policies onto base by AppName, so a per-app fragment file
+// can be shipped alongside the main configuration without clobbering other apps' policies.
+func mergeAppPolicies(base, override []AppPolicySpec) []AppPolicySpec {
+	byName := make(map[string]AppPolicySpec, len(base))
+	order := make([]string, 0, len(base))
+	for _, policy := range base {
+		if _, exists := byName[policy.AppName]; !exists {
+			order = append(order, policy.AppName)
+		}
+		byName[policy.AppName] = policy
+	}
+	for _, policy := range override {
+		if _, exists := byName[policy.AppName]; !exists {
+			order = append(order, policy.AppName)
+		}
+		byName[policy.AppName] = policy
+	}
+
+	merged := make([]AppPolicySpec, 0, len(order))
+	for _, name := range order {
+		merged = append(merged, byName[name])
+	}
+	return merged
+}
+
+// decode unmarshals b into a ConfigurationSpec using format, falling back to YAML (a superset
+// of JSON) when format is empty. Each decoded document is validated on its own, because merging
+// by StoreName and AppName would otherwise collapse duplicates within one document unseen.
+func decode(b []byte, format Format) (ConfigurationSpec, error) {
+	var conf Configuration
+
+	switch format {
+	case FormatJSON:
+		if err := json.Unmarshal(b, &conf); err != nil {
+			return ConfigurationSpec{}, err
+		}
+	case FormatHCL:
+		if err := hcl.Unmarshal(b, &conf); err != nil {
+			return ConfigurationSpec{}, err
+		}
+	case FormatYAML, "":
+		if err := yaml.Unmarshal(b, &conf); err != nil {
+			return ConfigurationSpec{}, err
+		}
+	default:
+		return ConfigurationSpec{}, errors.Errorf("unsupported configuration format %q", format)
+	}
+
+	if err := sortAndValidateSecretsConfiguration(&conf); err != nil {
+		return ConfigurationSpec{}, err
+	}
+	if err := validateConfigurationSpec(conf.Spec); err != nil {
+		return ConfigurationSpec{}, err
+	}
+
+	return conf.Spec, nil
+}
+
+// formatFromExtension infers a Format from a file extension such as ".yaml" or ".json".
+func formatFromExtension(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".hcl":
+		return FormatHCL
+	default:
+		return FormatYAML
+	}
+}
+
+// FileProvider loads a ConfigurationSpec from a single file. Format is inferred from the file
+// extension unless explicitly set.
+type FileProvider struct {
+	Path   string
+	Format Format
+}
+
+// Load implements Provider.
+func (p *FileProvider) Load(ctx context.Context) (ConfigurationSpec, error) {
+	b, err := ioutil.ReadFile(p.Path)
+	if err != nil {
+		return ConfigurationSpec{}, err
+	}
+
+	format := p.Format
+	if format == "" {
+		format = formatFromExtension(p.Path)
+	}
+	return decode(b, format)
+}
+
+// DirectoryProvider loads and merges every configuration fragment file in a directory, so that,
+// for example, accessControl.policies can be shipped one file per app.
+type DirectoryProvider struct {
+	Path string
+}
+
+// Load implements Provider.
+func (p *DirectoryProvider) Load(ctx context.Context) (ConfigurationSpec, error) {
+	entries, err := ioutil.ReadDir(p.Path)
+	if err != nil {
+		return ConfigurationSpec{}, err
+	}
+
+	var merged ConfigurationSpec
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		fragmentProvider := &FileProvider{Path: filepath.Join(p.Path, entry.Name())}
+		fragment, err := fragmentProvider.Load(ctx)
+		if err != nil {
+			return ConfigurationSpec{}, errors.Wrapf(err, "failed to load configuration fragment %s", entry.Name())
+		}
+		merged = mergeConfigurationSpec(merged, fragment)
+	}
+
+	return merged, nil
+}
+
+// EnvProvider loads well-known ConfigurationSpec fields from environment variables prefixed
+// with DAPR_CFG_, so a handful of settings can be overridden without a file, e.g.
+// DAPR_CFG_TRACING_SAMPLINGRATE or DAPR_CFG_METRIC_ENABLED.
+type EnvProvider struct{}
+
+// Load implements Provider.
+func (p *EnvProvider) Load(ctx context.Context) (ConfigurationSpec, error) {
+	var spec ConfigurationSpec
+
+	if v, ok := os.LookupEnv(envProviderPrefix + "TRACING_SAMPLINGRATE"); ok {
+		spec.TracingSpec.SamplingRate = v
+	}
+	if v, ok := os.LookupEnv(envProviderPrefix + "TRACING_STDOUT"); ok {
+		spec.TracingSpec.Stdout = strings.EqualFold(v, "true")
+	}
+	if v, ok := os.LookupEnv(envProviderPrefix + "METRIC_ENABLED"); ok {
+		spec.MetricSpec.Enabled = strings.EqualFold(v, "true")
+	}
+	if v, ok := os.LookupEnv(envProviderPrefix + "ACCESSCONTROL_DEFAULTACTION"); ok {
+		spec.AccessControlSpec.DefaultAction = v
+	}
+
+	return spec, nil
+}
+
+// KubernetesProvider loads a ConfigurationSpec from the operator. LoadKubernetesConfiguration is
+// a thin wrapper around a ConfigLoader built from this Provider, so Load fetches and decodes
+// directly rather than calling back into LoadKubernetesConfiguration.
+type KubernetesProvider struct {
+	Name           string
+	Namespace      string
+	OperatorClient operatorv1pb.OperatorClient
+}
+
+// Load implements Provider.
+func (p *KubernetesProvider) Load(ctx context.Context) (ConfigurationSpec, error) {
+	resp, err := p.OperatorClient.GetConfiguration(ctx, &operatorv1pb.GetConfigurationRequest{
+		Name:      p.Name,
+		Namespace: p.Namespace,
+	}, grpc_retry.WithMax(operatorMaxRetries), grpc_retry.WithPerRetryTimeout(operatorCallTimeout))
+	if err != nil {
+		return ConfigurationSpec{}, err
+	}
+	if resp.GetConfiguration() == nil {
+		return ConfigurationSpec{}, errors.Errorf("configuration %s not found", p.Name)
+	}
+
+	return decode(resp.GetConfiguration(), FormatJSON)
+}
+
+// HTTPProvider loads a ConfigurationSpec from a URL. Unless Format is explicitly set, it is
+// inferred from the extension at the very end of the URL string; a URL ending in a query or
+// fragment is therefore decoded as YAML.
+type HTTPProvider struct {
+	URL    string
+	Format Format
+}
+
+// Load implements Provider.
+func (p *HTTPProvider) Load(ctx context.Context) (ConfigurationSpec, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return ConfigurationSpec{}, err
+	}
+
+	client := &http.Client{Timeout: httpProviderTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ConfigurationSpec{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ConfigurationSpec{}, errors.Errorf("failed to fetch configuration from %s: status %d", p.URL, resp.StatusCode)
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return ConfigurationSpec{}, err
+	}
+
+	format := p.Format
+	if format == "" {
+		format = formatFromExtension(p.URL)
+	}
+	return decode(b, format)
+}
+
+// validateConfigurationSpec runs cross-field validators over a merged ConfigurationSpec that
+// sortAndValidateSecretsConfiguration, which only looks at the secrets scopes, does not cover.
+func validateConfigurationSpec(spec ConfigurationSpec) error {
+	seenApps := map[string]bool{}
+	for _, policy := range spec.AccessControlSpec.AppPolicies {
+		if seenApps[policy.AppName] {
+			return errors.Errorf("app %q has more than one access control policy", policy.AppName)
+		}
+		seenApps[policy.AppName] = true
+
+		if err := ValidateTrustDomainSyntax(policy.TrustDomain); err != nil {
+			return errors.Wrapf(err, "app %q", policy.AppName)
+		}
+		if err := ValidateNamePolicySpec(policy.NamePolicy); err != nil {
+			return errors.Wrapf(err, "app %q has an invalid namePolicy", policy.AppName)
+		}
+	}
+
+	for _, handler := range spec.HTTPPipelineSpec.Handlers {
+		if len(RegisteredHandlerTypes) > 0 && !RegisteredHandlerTypes[handler.Type] {
+			return errors.Errorf("pipeline handler %q has unknown type %q", handler.Name, handler.Type)
+		}
+	}
+
+	return nil
+}
+
+// ValidateTrustDomainSyntax accepts "*", a glob containing "*", a "re:" prefixed regular
+// expression (compiled lazily by the name policy matcher), or a bare DNS-label-like trust
+// domain. It is the single source of truth for trust-domain syntax; callers outside this
+// package (e.g. the admin API) should use it rather than re-implementing the pattern.
+func ValidateTrustDomainSyntax(trustDomain string) error {
+	if trustDomain == "" || strings.Contains(trustDomain, "*") || strings.HasPrefix(trustDomain, "re:") {
+		return nil
+	}
+	if !trustDomainPattern.MatchString(trustDomain) {
+		return errors.Errorf("invalid trustDomain %q", trustDomain)
+	}
+	return nil
+}
+
+// RegisteredHandlerTypes, when non-empty, restricts HTTPPipelineSpec handler types accepted by
+// validateConfigurationSpec to this set. Packages that register pipeline handler types are
+// expected to populate it; it is left empty (no restriction) by default.
+var RegisteredHandlerTypes = map[string]bool{}
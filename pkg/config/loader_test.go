@@ -0,0 +1,134 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTempFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestConfigLoaderMergesFragmentsByAppName(t *testing.T) {
+	dir := t.TempDir()
+	base := writeTempFile(t, dir, "base.yaml", `
+spec:
+  accessControl:
+    defaultAction: deny
+    policies:
+      - app: orderapp
+        defaultAction: allow
+`)
+	override := writeTempFile(t, dir, "override.yaml", `
+spec:
+  accessControl:
+    policies:
+      - app: invoiceapp
+        defaultAction: deny
+`)
+
+	loader := NewConfigLoader(&FileProvider{Path: base}, &FileProvider{Path: override})
+	conf, err := loader.Load(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "deny", conf.Spec.AccessControlSpec.DefaultAction)
+	assert.Len(t, conf.Spec.AccessControlSpec.AppPolicies, 2)
+}
+
+func TestConfigLoaderLaterProviderOverridesScalar(t *testing.T) {
+	dir := t.TempDir()
+	base := writeTempFile(t, dir, "base.yaml", `
+spec:
+  accessControl:
+    defaultAction: allow
+`)
+	override := writeTempFile(t, dir, "override.yaml", `
+spec:
+  accessControl:
+    defaultAction: deny
+`)
+
+	loader := NewConfigLoader(&FileProvider{Path: base}, &FileProvider{Path: override})
+	conf, err := loader.Load(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "deny", conf.Spec.AccessControlSpec.DefaultAction)
+}
+
+func TestConfigLoaderRejectsDuplicateAppPolicies(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTempFile(t, dir, "conf.json", `{
+		"spec": {
+			"accessControl": {
+				"policies": [
+					{"app": "orderapp"},
+					{"app": "orderapp"}
+				]
+			}
+		}
+	}`)
+
+	loader := NewConfigLoader(&FileProvider{Path: path, Format: FormatJSON})
+	_, err := loader.Load(context.Background())
+	assert.Error(t, err)
+}
+
+func TestConfigLoaderRejectsInvalidTrustDomain(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTempFile(t, dir, "conf.yaml", `
+spec:
+  accessControl:
+    policies:
+      - app: orderapp
+        trustDomain: "not a valid domain!"
+`)
+
+	loader := NewConfigLoader(&FileProvider{Path: path})
+	_, err := loader.Load(context.Background())
+	assert.Error(t, err)
+}
+
+func TestDirectoryProviderMergesAllFragments(t *testing.T) {
+	dir := t.TempDir()
+	writeTempFile(t, dir, "orderapp.yaml", `
+spec:
+  accessControl:
+    policies:
+      - app: orderapp
+`)
+	writeTempFile(t, dir, "invoiceapp.yaml", `
+spec:
+  accessControl:
+    policies:
+      - app: invoiceapp
+`)
+
+	provider := &DirectoryProvider{Path: dir}
+	spec, err := provider.Load(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, spec.AccessControlSpec.AppPolicies, 2)
+}
+
+func TestEnvProviderReadsKnownVariables(t *testing.T) {
+	os.Setenv("DAPR_CFG_TRACING_SAMPLINGRATE", "0.5")
+	defer os.Unsetenv("DAPR_CFG_TRACING_SAMPLINGRATE")
+
+	provider := &EnvProvider{}
+	spec, err := provider.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "0.5", spec.TracingSpec.SamplingRate)
+}
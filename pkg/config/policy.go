@@ -0,0 +1,166 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package config
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Matcher reports whether a single value (an operation, a trust domain or a namespace) matches
+// a configured pattern. Patterns are literals, globs ("*.foo.bar", "/v1/orders/*") or regular
+// expressions prefixed with "re:".
+type Matcher interface {
+	Match(value string) bool
+}
+
+type literalMatcher string
+
+func (m literalMatcher) Match(value string) bool {
+	return string(m) == value
+}
+
+type regexMatcher struct {
+	re *regexp.Regexp
+}
+
+func (m regexMatcher) Match(value string) bool {
+	return m.re.MatchString(value)
+}
+
+// compileMatcher compiles a single pattern into a Matcher. Patterns prefixed with "re:" are
+// compiled as anchored regular expressions; patterns containing "*" are compiled as anchored
+// globs where "*" matches any run of characters; everything else is matched literally. A
+// malformed "re:" pattern returns an error rather than silently degrading into a no-op matcher,
+// since a deny-list entry that can never match is a fail-open footgun for access control.
+func compileMatcher(pattern string) (Matcher, error) {
+	if strings.HasPrefix(pattern, "re:") {
+		expr := strings.TrimPrefix(pattern, "re:")
+		if _, err := regexp.Compile(expr); err != nil {
+			return nil, errors.Wrapf(err, "invalid regex pattern %q", pattern)
+		}
+		return regexMatcher{re: regexp.MustCompile("^(?:" + expr + ")$")}, nil
+	}
+
+	if strings.Contains(pattern, "*") {
+		parts := strings.Split(pattern, "*")
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		re := regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+		return regexMatcher{re: re}, nil
+	}
+
+	return literalMatcher(pattern), nil
+}
+
+func compileMatchers(patterns []string) ([]Matcher, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	matchers := make([]Matcher, len(patterns))
+	for i, pattern := range patterns {
+		m, err := compileMatcher(pattern)
+		if err != nil {
+			return nil, err
+		}
+		matchers[i] = m
+	}
+	return matchers, nil
+}
+
+// NamePolicy is the compiled form of a NamePolicySpec: ordered allow/deny matcher lists for
+// operations, trust domains and namespaces, evaluated with deny-wins-over-allow precedence.
+type NamePolicy struct {
+	allowedOperations   []Matcher
+	deniedOperations    []Matcher
+	allowedTrustDomains []Matcher
+	deniedTrustDomains  []Matcher
+	allowedNamespaces   []Matcher
+	deniedNamespaces    []Matcher
+}
+
+// compileNamePolicy compiles a NamePolicySpec once, at translation time, into a NamePolicy.
+// Callers that accept a NamePolicySpec from outside the process (config files, the admin API)
+// should validate it with ValidateNamePolicySpec first, since a malformed pattern here returns
+// an error rather than silently degrading into a no-op matcher.
+func compileNamePolicy(spec NamePolicySpec) (*NamePolicy, error) {
+	var np NamePolicy
+	var err error
+
+	if np.allowedOperations, err = compileMatchers(spec.AllowedOperations); err != nil {
+		return nil, errors.Wrap(err, "allowedOperations")
+	}
+	if np.deniedOperations, err = compileMatchers(spec.DeniedOperations); err != nil {
+		return nil, errors.Wrap(err, "deniedOperations")
+	}
+	if np.allowedTrustDomains, err = compileMatchers(spec.AllowedTrustDomains); err != nil {
+		return nil, errors.Wrap(err, "allowedTrustDomains")
+	}
+	if np.deniedTrustDomains, err = compileMatchers(spec.DeniedTrustDomains); err != nil {
+		return nil, errors.Wrap(err, "deniedTrustDomains")
+	}
+	if np.allowedNamespaces, err = compileMatchers(spec.AllowedNamespaces); err != nil {
+		return nil, errors.Wrap(err, "allowedNamespaces")
+	}
+	if np.deniedNamespaces, err = compileMatchers(spec.DeniedNamespaces); err != nil {
+		return nil, errors.Wrap(err, "deniedNamespaces")
+	}
+
+	return &np, nil
+}
+
+// ValidateNamePolicySpec pre-compiles every pattern in spec, returning an error if any "re:"
+// entry is not a valid regular expression.
+func ValidateNamePolicySpec(spec NamePolicySpec) error {
+	_, err := compileNamePolicy(spec)
+	return err
+}
+
+func matchAny(matchers []Matcher, value string) bool {
+	for _, m := range matchers {
+		if m.Match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluate applies the deny-wins-over-allow precedence shared by operations, trust domains and
+// namespaces: an explicit deny always wins; if an allow list is configured the value must match
+// it; otherwise, with no allow list configured, the value is allowed.
+func evaluate(allowed, denied []Matcher, value string) bool {
+	if matchAny(denied, value) {
+		return false
+	}
+	if len(allowed) > 0 {
+		return matchAny(allowed, value)
+	}
+	return true
+}
+
+func (p *NamePolicy) allowsOperation(operation string) bool {
+	if p == nil {
+		return true
+	}
+	return evaluate(p.allowedOperations, p.deniedOperations, operation)
+}
+
+func (p *NamePolicy) allowsTrustDomain(trustDomain string) bool {
+	if p == nil {
+		return true
+	}
+	return evaluate(p.allowedTrustDomains, p.deniedTrustDomains, trustDomain)
+}
+
+func (p *NamePolicy) allowsNamespace(namespace string) bool {
+	if p == nil {
+		return true
+	}
+	return evaluate(p.allowedNamespaces, p.deniedNamespaces, namespace)
+}
@@ -0,0 +1,127 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileMatcherLiteral(t *testing.T) {
+	m, err := compileMatcher("orderapp")
+	require.NoError(t, err)
+	assert.True(t, m.Match("orderapp"))
+	assert.False(t, m.Match("orderapp2"))
+}
+
+func TestCompileMatcherGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{"*.foo.bar", "baz.foo.bar", true},
+		{"*.foo.bar", "foo.bar", true},
+		{"*.foo.bar", "baz.foo.barz", false},
+		{"/v1/orders/*", "/v1/orders/123", true},
+		{"/v1/orders/*", "/v1/orders/123/items", true},
+		{"/v1/orders/*", "/v1/other", false},
+	}
+	for _, tt := range tests {
+		m, err := compileMatcher(tt.pattern)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, m.Match(tt.value), "pattern %q value %q", tt.pattern, tt.value)
+	}
+}
+
+func TestCompileMatcherRegexAnchored(t *testing.T) {
+	m, err := compileMatcher("re:^/v1/orders/[0-9]+$")
+	require.NoError(t, err)
+	assert.True(t, m.Match("/v1/orders/123"))
+	assert.False(t, m.Match("/v1/orders/123/items"))
+	assert.False(t, m.Match("x/v1/orders/123"))
+}
+
+func TestCompileMatcherInvalidRegexReturnsError(t *testing.T) {
+	_, err := compileMatcher("re:^/v1/secrets/(.*")
+	assert.Error(t, err)
+}
+
+func TestCompileNamePolicyRejectsInvalidRegex(t *testing.T) {
+	_, err := compileNamePolicy(NamePolicySpec{
+		DeniedOperations: []string{"re:^/v1/secrets/(.*"},
+	})
+	assert.Error(t, err)
+}
+
+func TestValidateNamePolicySpecRejectsInvalidRegex(t *testing.T) {
+	err := ValidateNamePolicySpec(NamePolicySpec{
+		AllowedTrustDomains: []string{"re:^partner-("},
+	})
+	assert.Error(t, err)
+}
+
+func TestValidateNamePolicySpecAcceptsValidPatterns(t *testing.T) {
+	err := ValidateNamePolicySpec(NamePolicySpec{
+		AllowedOperations:   []string{"/v1/orders/*"},
+		AllowedTrustDomains: []string{"re:^partner-.*$"},
+	})
+	assert.NoError(t, err)
+}
+
+func TestNamePolicyDenyWinsOverAllow(t *testing.T) {
+	p, err := compileNamePolicy(NamePolicySpec{
+		AllowedOperations: []string{"/v1/orders/*"},
+		DeniedOperations:  []string{"/v1/orders/secret"},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, p.allowsOperation("/v1/orders/123"))
+	assert.False(t, p.allowsOperation("/v1/orders/secret"))
+}
+
+func TestNamePolicyAllowListMustMatch(t *testing.T) {
+	p, err := compileNamePolicy(NamePolicySpec{
+		AllowedOperations: []string{"/v1/orders/*"},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, p.allowsOperation("/v1/orders/123"))
+	assert.False(t, p.allowsOperation("/v1/invoices/123"))
+}
+
+func TestNamePolicyNoListsDefaultsToAllow(t *testing.T) {
+	p, err := compileNamePolicy(NamePolicySpec{})
+	require.NoError(t, err)
+
+	assert.True(t, p.allowsOperation("/v1/orders/123"))
+	assert.True(t, p.allowsTrustDomain("public"))
+	assert.True(t, p.allowsNamespace("default"))
+}
+
+func TestNilNamePolicyDefaultsToAllow(t *testing.T) {
+	var p *NamePolicy
+
+	assert.True(t, p.allowsOperation("/v1/orders/123"))
+	assert.True(t, p.allowsTrustDomain("public"))
+	assert.True(t, p.allowsNamespace("default"))
+}
+
+func TestNamePolicyTrustDomainAndNamespace(t *testing.T) {
+	p, err := compileNamePolicy(NamePolicySpec{
+		AllowedTrustDomains: []string{"public", "re:^partner-.*$"},
+		DeniedNamespaces:    []string{"kube-system"},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, p.allowsTrustDomain("public"))
+	assert.True(t, p.allowsTrustDomain("partner-a"))
+	assert.False(t, p.allowsTrustDomain("internal"))
+	assert.True(t, p.allowsNamespace("default"))
+	assert.False(t, p.allowsNamespace("kube-system"))
+}
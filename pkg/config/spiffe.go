@@ -0,0 +1,165 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package config
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// Errors returned while retrieving or parsing a SPIFFE id from the grpc peer context.
+var (
+	ErrNoPeerContext       = errors.New("could not retrieve peer from the grpc context")
+	ErrNoPeerAuthInfo      = errors.New("could not retrieve auth info from the grpc peer context")
+	ErrTLSHandshakeNotDone = errors.New("tls handshake is not complete")
+	ErrNoVerifiedCertChain = errors.New("could not retrieve a verified client cert chain")
+	ErrNoSpiffeID          = errors.New("no spiffe id found in the leaf certificate's URI SANs")
+	ErrMalformedSpiffeID   = errors.New("spiffe id is not of the format spiffe://<trust-domain>/ns/<namespace>/<app-id>")
+)
+
+// SpiffeID represents the separated fields in a spiffe id.
+type SpiffeID struct {
+	trustDomain string
+	namespace   string
+	appID       string
+}
+
+// TrustDomain returns the trust domain component of the spiffe id.
+func (s *SpiffeID) TrustDomain() string {
+	return s.trustDomain
+}
+
+// Namespace returns the namespace component of the spiffe id.
+func (s *SpiffeID) Namespace() string {
+	return s.namespace
+}
+
+// AppID returns the app id component of the spiffe id.
+func (s *SpiffeID) AppID() string {
+	return s.appID
+}
+
+// SpiffeIDValidator allows a caller to enforce additional constraints on a SpiffeID,
+// for example restricting the accepted trust domain or namespace.
+type SpiffeIDValidator interface {
+	Validate(id *SpiffeID) error
+}
+
+// spiffeIDValidators holds the validators registered via RegisterSpiffeIDValidator.
+var spiffeIDValidators []SpiffeIDValidator
+
+// RegisterSpiffeIDValidator registers a validator that is consulted, in registration order,
+// every time a SpiffeID is checked against an access control policy.
+func RegisterSpiffeIDValidator(validator SpiffeIDValidator) {
+	spiffeIDValidators = append(spiffeIDValidators, validator)
+}
+
+// validateSpiffeID runs id through every registered SpiffeIDValidator, returning the first error.
+func validateSpiffeID(id *SpiffeID) error {
+	for _, validator := range spiffeIDValidators {
+		if err := validator.Validate(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TrustDomainValidator rejects any SpiffeID whose trust domain is not in the allowed set.
+type TrustDomainValidator struct {
+	AllowedTrustDomains []string
+}
+
+// Validate implements SpiffeIDValidator.
+func (v *TrustDomainValidator) Validate(id *SpiffeID) error {
+	for _, trustDomain := range v.AllowedTrustDomains {
+		if trustDomain == id.trustDomain {
+			return nil
+		}
+	}
+	return errors.Errorf("trust domain %q is not allowed", id.trustDomain)
+}
+
+// NamespaceValidator rejects any SpiffeID whose namespace is not in the allowed set.
+type NamespaceValidator struct {
+	AllowedNamespaces []string
+}
+
+// Validate implements SpiffeIDValidator.
+func (v *NamespaceValidator) Validate(id *SpiffeID) error {
+	for _, namespace := range v.AllowedNamespaces {
+		if namespace == id.namespace {
+			return nil
+		}
+	}
+	return errors.Errorf("namespace %q is not allowed", id.namespace)
+}
+
+// TryGetAndParseSpiffeID retrieves the SPIFFE id from the verified client certificate on the
+// grpc peer connection and parses it.
+func TryGetAndParseSpiffeID(ctx context.Context) (*SpiffeID, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, ErrNoPeerContext
+	}
+	return getSpiffeIDFromPeer(p)
+}
+
+func getSpiffeIDFromPeer(p *peer.Peer) (*SpiffeID, error) {
+	if p.AuthInfo == nil {
+		return nil, ErrNoPeerAuthInfo
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil, ErrNoPeerAuthInfo
+	}
+
+	if !tlsInfo.State.HandshakeComplete {
+		return nil, ErrTLSHandshakeNotDone
+	}
+
+	certChain := tlsInfo.State.VerifiedChains
+	if len(certChain) == 0 || len(certChain[0]) == 0 {
+		return nil, ErrNoVerifiedCertChain
+	}
+
+	leaf := certChain[0][0]
+	for _, uri := range leaf.URIs {
+		if uri.Scheme == "spiffe" {
+			return ParseSpiffeID(uri.String())
+		}
+	}
+
+	return nil, ErrNoSpiffeID
+}
+
+// ParseSpiffeID parses a spiffe URI of the form spiffe://<trust-domain>/ns/<namespace>/<app-id>.
+func ParseSpiffeID(spiffeID string) (*SpiffeID, error) {
+	u, err := url.Parse(spiffeID)
+	if err != nil {
+		return nil, errors.Wrap(ErrMalformedSpiffeID, err.Error())
+	}
+
+	if u.Scheme != "spiffe" || u.Host == "" {
+		return nil, ErrMalformedSpiffeID
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 3 || parts[0] != "ns" || parts[1] == "" || parts[2] == "" {
+		return nil, ErrMalformedSpiffeID
+	}
+
+	return &SpiffeID{
+		trustDomain: u.Host,
+		namespace:   parts[1],
+		appID:       parts[2],
+	}, nil
+}
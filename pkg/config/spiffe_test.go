@@ -0,0 +1,142 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package config
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+type fakeAuthInfo struct{}
+
+func (fakeAuthInfo) AuthType() string { return "fake" }
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return u
+}
+
+func TestParseSpiffeIDValid(t *testing.T) {
+	id, err := ParseSpiffeID("spiffe://example.org/ns/default/orderapp")
+	require.NoError(t, err)
+	assert.Equal(t, "example.org", id.TrustDomain())
+	assert.Equal(t, "default", id.Namespace())
+	assert.Equal(t, "orderapp", id.AppID())
+}
+
+func TestParseSpiffeIDMalformed(t *testing.T) {
+	tests := []string{
+		"http://example.org/ns/default/orderapp",
+		"spiffe:///ns/default/orderapp",
+		"spiffe://example.org/default/orderapp",
+		"spiffe://example.org/ns/default",
+		"spiffe://example.org/ns//orderapp",
+		"spiffe://example.org/ns/default/",
+		"not a url at all \x7f",
+	}
+	for _, raw := range tests {
+		_, err := ParseSpiffeID(raw)
+		assert.Error(t, err, "expected error for %q", raw)
+	}
+}
+
+func TestGetSpiffeIDFromPeerNoAuthInfo(t *testing.T) {
+	_, err := getSpiffeIDFromPeer(&peer.Peer{})
+	assert.Equal(t, ErrNoPeerAuthInfo, err)
+}
+
+func TestGetSpiffeIDFromPeerNonTLSAuthInfo(t *testing.T) {
+	_, err := getSpiffeIDFromPeer(&peer.Peer{AuthInfo: fakeAuthInfo{}})
+	assert.Equal(t, ErrNoPeerAuthInfo, err)
+}
+
+func TestGetSpiffeIDFromPeerHandshakeNotComplete(t *testing.T) {
+	p := &peer.Peer{AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{HandshakeComplete: false}}}
+	_, err := getSpiffeIDFromPeer(p)
+	assert.Equal(t, ErrTLSHandshakeNotDone, err)
+}
+
+func TestGetSpiffeIDFromPeerEmptyChain(t *testing.T) {
+	p := &peer.Peer{AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+		HandshakeComplete: true,
+		VerifiedChains:    nil,
+	}}}
+	_, err := getSpiffeIDFromPeer(p)
+	assert.Equal(t, ErrNoVerifiedCertChain, err)
+}
+
+func TestGetSpiffeIDFromPeerNoSpiffeURI(t *testing.T) {
+	leaf := &x509.Certificate{URIs: []*url.URL{mustParseURL(t, "https://example.org/not-spiffe")}}
+	p := &peer.Peer{AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+		HandshakeComplete: true,
+		VerifiedChains:    [][]*x509.Certificate{{leaf}},
+	}}}
+	_, err := getSpiffeIDFromPeer(p)
+	assert.Equal(t, ErrNoSpiffeID, err)
+}
+
+func TestGetSpiffeIDFromPeerMalformedSpiffeURI(t *testing.T) {
+	leaf := &x509.Certificate{URIs: []*url.URL{mustParseURL(t, "spiffe://example.org/bad/path")}}
+	p := &peer.Peer{AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+		HandshakeComplete: true,
+		VerifiedChains:    [][]*x509.Certificate{{leaf}},
+	}}}
+	_, err := getSpiffeIDFromPeer(p)
+	assert.Error(t, err)
+}
+
+func TestGetSpiffeIDFromPeerSuccess(t *testing.T) {
+	leaf := &x509.Certificate{URIs: []*url.URL{mustParseURL(t, "spiffe://example.org/ns/default/orderapp")}}
+	p := &peer.Peer{AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+		HandshakeComplete: true,
+		VerifiedChains:    [][]*x509.Certificate{{leaf}},
+	}}}
+	id, err := getSpiffeIDFromPeer(p)
+	require.NoError(t, err)
+	assert.Equal(t, "example.org", id.TrustDomain())
+	assert.Equal(t, "default", id.Namespace())
+	assert.Equal(t, "orderapp", id.AppID())
+}
+
+func TestTryGetAndParseSpiffeIDNoPeerInContext(t *testing.T) {
+	_, err := TryGetAndParseSpiffeID(context.Background())
+	assert.Equal(t, ErrNoPeerContext, err)
+}
+
+func TestTrustDomainValidator(t *testing.T) {
+	v := &TrustDomainValidator{AllowedTrustDomains: []string{"example.org"}}
+	assert.NoError(t, v.Validate(&SpiffeID{trustDomain: "example.org"}))
+	assert.Error(t, v.Validate(&SpiffeID{trustDomain: "evil.org"}))
+}
+
+func TestNamespaceValidator(t *testing.T) {
+	v := &NamespaceValidator{AllowedNamespaces: []string{"default"}}
+	assert.NoError(t, v.Validate(&SpiffeID{namespace: "default"}))
+	assert.Error(t, v.Validate(&SpiffeID{namespace: "kube-system"}))
+}
+
+func TestRegisterSpiffeIDValidatorChain(t *testing.T) {
+	originalValidators := spiffeIDValidators
+	spiffeIDValidators = nil
+	defer func() { spiffeIDValidators = originalValidators }()
+
+	RegisterSpiffeIDValidator(&TrustDomainValidator{AllowedTrustDomains: []string{"example.org"}})
+	RegisterSpiffeIDValidator(&NamespaceValidator{AllowedNamespaces: []string{"default"}})
+
+	assert.NoError(t, validateSpiffeID(&SpiffeID{trustDomain: "example.org", namespace: "default"}))
+	assert.Error(t, validateSpiffeID(&SpiffeID{trustDomain: "evil.org", namespace: "default"}))
+	assert.Error(t, validateSpiffeID(&SpiffeID{trustDomain: "example.org", namespace: "kube-system"}))
+}
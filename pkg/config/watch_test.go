@@ -0,0 +1,220 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package config
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	operatorv1pb "github.com/dapr/dapr/pkg/proto/operator/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// fakeOperatorClient implements operatorv1pb.OperatorClient by embedding it (so every method this
+// test doesn't care about is left as a nil-panicking stub) and overriding GetConfiguration to
+// return a canned response.
+type fakeOperatorClient struct {
+	operatorv1pb.OperatorClient
+
+	mu  sync.Mutex
+	raw []byte
+	err error
+}
+
+func (f *fakeOperatorClient) setConfiguration(raw []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.raw = raw
+}
+
+func (f *fakeOperatorClient) GetConfiguration(ctx context.Context, in *operatorv1pb.GetConfigurationRequest, opts ...grpc.CallOption) (*operatorv1pb.GetConfigurationResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &operatorv1pb.GetConfigurationResponse{Configuration: f.raw}, nil
+}
+
+func TestKubernetesConfigurationSourceEmitsInitialConfiguration(t *testing.T) {
+	client := &fakeOperatorClient{raw: []byte(`{"spec":{"accessControl":{"defaultAction":"allow"}}}`)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	source := NewKubernetesConfigurationSource("daprsystem", "default", client)
+	out, err := source.Watch(ctx)
+	require.NoError(t, err)
+
+	select {
+	case conf := <-out:
+		require.NotNil(t, conf)
+		assert.Equal(t, "allow", conf.Spec.AccessControlSpec.DefaultAction)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the initial polled configuration")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-out:
+		assert.False(t, ok, "out should close once ctx is cancelled")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for out to close after context cancellation")
+	}
+}
+
+func TestKubernetesConfigurationSourcePollSkipsUnchangedContent(t *testing.T) {
+	raw := []byte(`{"spec":{"accessControl":{"defaultAction":"allow"}}}`)
+	client := &fakeOperatorClient{raw: raw}
+	source := &kubernetesConfigurationSource{name: "daprsystem", namespace: "default", operatorClient: client}
+
+	conf, firstRaw, err := source.poll(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, conf)
+
+	_, secondRaw, err := source.poll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, firstRaw, secondRaw)
+
+	client.setConfiguration([]byte(`{"spec":{"accessControl":{"defaultAction":"deny"}}}`))
+	conf, thirdRaw, err := source.poll(context.Background())
+	require.NoError(t, err)
+	assert.NotEqual(t, secondRaw, thirdRaw)
+	assert.Equal(t, "deny", conf.Spec.AccessControlSpec.DefaultAction)
+}
+
+func TestStandaloneConfigurationSourceEmitsReloadOnChange(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTempFile(t, dir, "config.yaml", "spec:\n  accessControl:\n    defaultAction: allow\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	source := NewStandaloneConfigurationSource(path)
+	out, err := source.Watch(ctx)
+	require.NoError(t, err)
+
+	writeTempFile(t, dir, "config.yaml", "spec:\n  accessControl:\n    defaultAction: deny\n")
+
+	select {
+	case conf := <-out:
+		require.NotNil(t, conf)
+		assert.Equal(t, "deny", conf.Spec.AccessControlSpec.DefaultAction)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for reloaded configuration")
+	}
+}
+
+func TestStandaloneConfigurationSourceCoalescesBurstIntoSingleReload(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTempFile(t, dir, "config.yaml", "spec:\n  accessControl:\n    defaultAction: allow\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	source := NewStandaloneConfigurationSource(path)
+	out, err := source.Watch(ctx)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		writeTempFile(t, dir, "config.yaml", "spec:\n  accessControl:\n    defaultAction: deny\n")
+	}
+
+	select {
+	case conf := <-out:
+		require.NotNil(t, conf)
+		assert.Equal(t, "deny", conf.Spec.AccessControlSpec.DefaultAction)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for reloaded configuration")
+	}
+
+	select {
+	case conf := <-out:
+		t.Fatalf("expected the burst of writes to coalesce into a single reload, got a second one: %+v", conf)
+	case <-time.After(watchDebounceInterval * 2):
+	}
+}
+
+func TestAccessControlListStoreReloadRejectsInvalidNamePolicy(t *testing.T) {
+	initial := &AccessControlList{DefaultAction: AccessControlActionAllow}
+	store := NewAccessControlListStore(initial)
+
+	err := store.Reload(AccessControlSpec{
+		DefaultAction: AccessControlActionAllow,
+		AppPolicies: []AppPolicySpec{
+			{
+				AppName: "orderapp",
+				NamePolicy: NamePolicySpec{
+					DeniedOperations: []string{"re:^/v1/secrets/(.*"},
+				},
+			},
+		},
+	}, "orderapp")
+	assert.Error(t, err)
+	assert.Same(t, initial, store.Get(), "a reload with an invalid namePolicy must keep the prior AccessControlList")
+}
+
+func TestAccessControlListStoreReloadRejectsInvalidTrustDomainSyntax(t *testing.T) {
+	initial := &AccessControlList{DefaultAction: AccessControlActionAllow}
+	store := NewAccessControlListStore(initial)
+
+	err := store.Reload(AccessControlSpec{
+		DefaultAction: AccessControlActionAllow,
+		AppPolicies: []AppPolicySpec{
+			{AppName: "orderapp", TrustDomain: "not a valid trust domain!"},
+		},
+	}, "orderapp")
+	assert.Error(t, err)
+	assert.Same(t, initial, store.Get())
+}
+
+func TestWatchAndReloadAppliesFileEditToLiveStore(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTempFile(t, dir, "config.yaml", "spec:\n  accessControl:\n    defaultAction: allow\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewAccessControlListStore(&AccessControlList{DefaultAction: AccessControlActionAllow})
+	source := NewStandaloneConfigurationSource(path)
+	require.NoError(t, WatchAndReload(ctx, source, store, "orderapp"))
+
+	writeTempFile(t, dir, "config.yaml", "spec:\n  accessControl:\n    defaultAction: deny\n")
+
+	require.Eventually(t, func() bool {
+		return store.Get().DefaultAction == AccessControlActionDeny
+	}, 5*time.Second, 10*time.Millisecond, "on-disk edit never reached the live AccessControlListStore")
+}
+
+// TestStandaloneConfigurationSourceStopsDebounceOnContextCancel guards against a goroutine/timer
+// leak: if the watch context is cancelled while a reload is debounced, the pending timer must not
+// fire a send on the already-closed out channel.
+func TestStandaloneConfigurationSourceStopsDebounceOnContextCancel(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTempFile(t, dir, "config.yaml", "spec:\n  accessControl:\n    defaultAction: allow\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	source := NewStandaloneConfigurationSource(path)
+	out, err := source.Watch(ctx)
+	require.NoError(t, err)
+
+	writeTempFile(t, dir, "config.yaml", "spec:\n  accessControl:\n    defaultAction: deny\n")
+
+	// Cancel immediately, before the debounce interval elapses, so the reload is still pending.
+	cancel()
+
+	select {
+	case _, ok := <-out:
+		assert.False(t, ok, "out should be closed without ever sending the debounced reload")
+	case <-time.After(watchDebounceInterval * 3):
+		t.Fatal("timed out waiting for out to close after context cancellation")
+	}
+}